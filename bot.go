@@ -0,0 +1,130 @@
+package gotbot
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+const apiBaseURL = "https://api.telegram.org/bot"
+
+// Bot is a Telegram Bot API client. Every call made through Do passes
+// through the Bot's middleware chain before reaching the wire - see Use
+// and the built-in RateLimitMiddleware, RetryAfterMiddleware and
+// BackoffMiddleware.
+type Bot struct {
+	Token  string
+	Client *http.Client
+
+	middlewares []Middleware
+}
+
+// NewBot creates a Bot for the given API token, wired up with middlewares
+// in the order given (outermost first).
+func NewBot(token string, middlewares ...Middleware) *Bot {
+	return &Bot{
+		Token:       token,
+		Client:      http.DefaultClient,
+		middlewares: middlewares,
+	}
+}
+
+// Use appends middlewares to the Bot's chain.
+func (b *Bot) Use(middlewares ...Middleware) {
+	b.middlewares = append(b.middlewares, middlewares...)
+}
+
+// apiResponse mirrors the envelope Telegram wraps every Bot API response in.
+type apiResponse struct {
+	OK          bool            `json:"ok"`
+	Result      json.RawMessage `json:"result"`
+	ErrorCode   int             `json:"error_code"`
+	Description string          `json:"description"`
+	Parameters  *struct {
+		RetryAfter      int   `json:"retry_after"`
+		MigrateToChatID int64 `json:"migrate_to_chat_id"`
+	} `json:"parameters"`
+}
+
+// APIError is returned when Telegram responds with "ok": false.
+type APIError struct {
+	Code        int
+	Description string
+	RetryAfter  int
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("gotbot: telegram API error %d: %s", e.Code, e.Description)
+}
+
+// Do sends method through the Bot's middleware chain, using setup (e.g.
+// SetApplicationJSON / SetMultipartFormData) to further customize the
+// *http.Request and body to (re)build the request body on every attempt.
+// On success it decodes Telegram's "result" field into result, which may
+// be nil when the caller doesn't need the response.
+func (b *Bot) Do(ctx context.Context, setup func(*http.Request) error, method string, body BodyFactory, result any) error {
+	rt := b.roundTrip
+	for i := len(b.middlewares) - 1; i >= 0; i-- {
+		rt = b.middlewares[i](rt)
+	}
+
+	resp, err := rt(&Request{Ctx: ctx, Method: method, Setup: setup, Body: body})
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	var apiResp apiResponse
+	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
+		return fmt.Errorf("gotbot: decoding response: %w", err)
+	}
+
+	if !apiResp.OK {
+		apiErr := &APIError{Code: apiResp.ErrorCode, Description: apiResp.Description}
+		if apiResp.Parameters != nil {
+			apiErr.RetryAfter = apiResp.Parameters.RetryAfter
+		}
+
+		return apiErr
+	}
+
+	if result == nil || len(apiResp.Result) == 0 {
+		return nil
+	}
+
+	return json.Unmarshal(apiResp.Result, result)
+}
+
+// roundTrip is the innermost RoundTrip: it builds the *http.Request for req
+// and sends it with the Bot's Client.
+func (b *Bot) roundTrip(req *Request) (*http.Response, error) {
+	r, opts, err := req.Body()
+	if err != nil {
+		return nil, err
+	}
+
+	// Recorded so retry middlewares can tell whether calling req.Body()
+	// again would be safe before they do so.
+	req.LastBodyOptions = opts
+
+	httpReq, err := http.NewRequestWithContext(req.Ctx, http.MethodPost, apiBaseURL+b.Token+"/"+req.Method, r)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Setup != nil {
+		if err := req.Setup(httpReq); err != nil {
+			return nil, err
+		}
+	}
+
+	// opts.ContentType (e.g. multipart/form-data with its boundary) is
+	// authoritative over whatever Setup set, since Setup predates
+	// per-request BodyOptions and can't know the boundary.
+	httpReq.Header.Set("Content-Type", opts.ContentType)
+
+	return b.Client.Do(httpReq)
+}
@@ -0,0 +1,85 @@
+package gotbot
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// roundTripperFunc adapts a function to http.RoundTripper.
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
+
+func newFakeBot(rt roundTripperFunc) *Bot {
+	return &Bot{Token: "TEST", Client: &http.Client{Transport: rt}}
+}
+
+func jsonResponse(status int, body string) *http.Response {
+	return &http.Response{StatusCode: status, Body: io.NopCloser(strings.NewReader(body))}
+}
+
+func TestBotDoSuccess(t *testing.T) {
+	bot := newFakeBot(func(req *http.Request) (*http.Response, error) {
+		if got, want := req.URL.String(), "https://api.telegram.org/botTEST/getMe"; got != want {
+			t.Errorf("URL = %q, want %q", got, want)
+		}
+
+		return jsonResponse(http.StatusOK, `{"ok":true,"result":{"id":42,"username":"test_bot"}}`), nil
+	})
+
+	var result struct {
+		ID       int64  `json:"id"`
+		Username string `json:"username"`
+	}
+
+	if err := bot.Do(context.Background(), SetApplicationJSON, "getMe", GetJSONBody(nil), &result); err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+
+	if result.ID != 42 || result.Username != "test_bot" {
+		t.Errorf("result = %+v, want {42 test_bot}", result)
+	}
+}
+
+func TestBotDoAPIError(t *testing.T) {
+	bot := newFakeBot(func(req *http.Request) (*http.Response, error) {
+		return jsonResponse(http.StatusBadRequest, `{"ok":false,"error_code":400,"description":"chat not found"}`), nil
+	})
+
+	err := bot.Do(context.Background(), SetApplicationJSON, "sendMessage", GetJSONBody(nil), nil)
+
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("err = %v, want *APIError", err)
+	}
+
+	if apiErr.Code != 400 || apiErr.Description != "chat not found" {
+		t.Errorf("apiErr = %+v, want {400 chat not found ...}", apiErr)
+	}
+}
+
+func TestBotDoDecodeError(t *testing.T) {
+	bot := newFakeBot(func(req *http.Request) (*http.Response, error) {
+		return jsonResponse(http.StatusOK, `not json`), nil
+	})
+
+	if err := bot.Do(context.Background(), SetApplicationJSON, "getMe", GetJSONBody(nil), nil); err == nil {
+		t.Fatal("expected a decode error, got nil")
+	}
+}
+
+func TestBotDoNetworkError(t *testing.T) {
+	wantErr := errors.New("connection refused")
+
+	bot := newFakeBot(func(req *http.Request) (*http.Response, error) {
+		return nil, wantErr
+	})
+
+	if err := bot.Do(context.Background(), SetApplicationJSON, "getMe", GetJSONBody(nil), nil); !errors.Is(err, wantErr) {
+		t.Fatalf("err = %v, want %v", err, wantErr)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"io"
 	"mime/multipart"
 	"net/http"
+	"net/textproto"
 	"os"
 	"path/filepath"
 	"reflect"
@@ -29,93 +30,342 @@ var (
 	}
 )
 
+// BodyFactory builds a fresh request body. Bot.Do calls it once per HTTP
+// attempt so that retried requests - including multipart ones backed by a
+// file's Path or Bytes - can be re-streamed from scratch; a FileEnvelop
+// whose source is a bare io.Reader/io.ReadCloser can only be consumed once
+// and so cannot be safely retried.
+type BodyFactory func() (io.Reader, BodyOptions, error)
+
 // body setters
 var (
-	// GetJSONBody marshals a given object to a json serialized string
-	GetJSONBody = func(value any) (io.Reader, BodyOptions, error) {
-		body, err := json.Marshal(value)
-		return bytes.NewBuffer(body), BodyOptions{ContentType: "application/json"}, err
-	}
-	// GetMultipartBody creates a form data with the given fields and files.
-	// if `files` contains an element with the same name in `msg`, only the file is added to the body.
-	GetMultipartBody = func(msg any, files ...entity.FileEnvelop) (io.Reader, BodyOptions, error) {
-		msgValue := reflect.ValueOf(msg)
-		body := &bytes.Buffer{}
-		writer := multipart.NewWriter(body)
-
-		for i := 0; i < msgValue.NumField(); i++ {
-			fieldName := coalesce(
-				strings.Split(reflect.TypeOf(msg).Field(i).Tag.Get("json"), ",")[0],
-				reflect.TypeOf(msg).Field(i).Name)
-			var skip bool
-
-			for j := 0; j < len(files); j++ {
-				if files[j].Name == fieldName {
-					skip = true
-					break
+	// GetJSONBody returns a BodyFactory that marshals value to a json
+	// serialized string. Calling it again always reproduces the same
+	// body, so its BodyOptions.Replayable is always true.
+	GetJSONBody = func(value any) BodyFactory {
+		return func() (io.Reader, BodyOptions, error) {
+			body, err := json.Marshal(value)
+			return bytes.NewBuffer(body), BodyOptions{ContentType: "application/json", Replayable: true}, err
+		}
+	}
+	// GetMultipartBody returns a BodyFactory that creates a form data with
+	// the given fields and files, streaming the body through an io.Pipe
+	// instead of buffering it in memory. if `files` contains an element
+	// with the same name in `msg`, only the file is added to the body.
+	//
+	// Each call to the returned BodyFactory starts its own multipart.Writer
+	// goroutine and io.Pipe; the returned io.Reader can be read exactly
+	// once per call, so large files (or file sources that are themselves
+	// streams, e.g. os.Stdin or an HTTP response body) never need to be
+	// fully resident in memory. BodyOptions.Replayable is false whenever
+	// any file is backed by a bare Reader/ReadCloser, since calling the
+	// BodyFactory again would resend an already-drained (empty) part.
+	GetMultipartBody = func(msg any, files ...entity.FileEnvelop) BodyFactory {
+		replayable := true
+		for _, file := range files {
+			if !file.Replayable() {
+				replayable = false
+				break
+			}
+		}
+
+		return func() (io.Reader, BodyOptions, error) {
+			pr, pw := io.Pipe()
+			writer := multipart.NewWriter(pw)
+
+			go func() {
+				defer func() {
+					if r := recover(); r != nil {
+						_ = pw.CloseWithError(fmt.Errorf("gotbot: panic writing multipart body: %v", r))
+					}
+				}()
+
+				err := writeMultipartFields(writer, msg, files)
+				if err == nil {
+					err = writeMultipartFiles(writer, files)
+				}
+				if err == nil {
+					err = writer.Close()
 				}
+
+				_ = pw.CloseWithError(err)
+			}()
+
+			return pr, BodyOptions{ContentType: writer.FormDataContentType(), Replayable: replayable}, nil
+		}
+	}
+)
+
+// GetBody picks GetJSONBody or GetMultipartBody for msg depending on
+// whether any of its fields hold an entity.InputFile that needs to be
+// uploaded (entity.FromPath, FromReader or FromBytes, as opposed to
+// FromFileID or FromURL), so callers no longer have to pre-decide between
+// JSON and multipart before building the message.
+func GetBody(msg any) (BodyFactory, error) {
+	fields, err := collectJSONFields(reflect.ValueOf(msg))
+	if err != nil {
+		return nil, err
+	}
+
+	var files []entity.FileEnvelop
+
+	for _, field := range fields {
+		value := field.value
+		for value.Kind() == reflect.Ptr {
+			if value.IsNil() {
+				break
 			}
+			value = value.Elem()
+		}
+
+		if value.Kind() == reflect.Ptr {
+			continue // nil
+		}
+
+		inputFile, ok := value.Interface().(entity.InputFile)
+		if !ok || !inputFile.NeedsUpload() {
+			continue
+		}
+
+		files = append(files, inputFile.Envelop(field.name))
+	}
+
+	if len(files) == 0 {
+		return GetJSONBody(msg), nil
+	}
+
+	return GetMultipartBody(msg, files...), nil
+}
+
+// writeMultipartFields writes every field of msg, except the ones
+// overridden by a same-named file in files, as a form field.
+func writeMultipartFields(writer *multipart.Writer, msg any, files []entity.FileEnvelop) error {
+	fields, err := collectJSONFields(reflect.ValueOf(msg))
+	if err != nil {
+		return err
+	}
+
+	for _, field := range fields {
+		var skip bool
 
-			if strings.Contains(
-				reflect.TypeOf(msg).Field(i).Tag.Get("json"),
-				",omitempty") &&
-				msgValue.Field(i).IsZero() {
+		for j := 0; j < len(files); j++ {
+			if files[j].Name == field.name {
 				skip = true
+				break
 			}
+		}
 
-			if skip {
-				continue
-			}
+		if skip {
+			continue
+		}
 
-			var value string
+		value, err := marshalFieldValue(field.value)
+		if err != nil {
+			return err
+		}
 
-			switch msgValue.Field(i).Kind() {
-			case reflect.Struct, reflect.Map, reflect.Array, reflect.Slice:
-				js, err := json.Marshal(msgValue.Field(i).Interface())
-				if err != nil {
-					return nil, BodyOptions{}, err
-				}
+		if err := writer.WriteField(field.name, value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// jsonField is a struct field paired with the form field name it should be
+// written under.
+type jsonField struct {
+	name  string
+	value reflect.Value
+}
+
+// collectJSONFields walks msgValue's struct fields the way encoding/json
+// does: embedded, exported struct fields without their own json tag name
+// are flattened into their parent instead of being emitted as one nested
+// field, and fields tagged ",omitempty" are dropped when they are empty by
+// encoding/json's own definition (not just reflect.Value.IsZero, which
+// disagrees with it for e.g. an empty non-nil slice or a zero struct).
+func collectJSONFields(msgValue reflect.Value) ([]jsonField, error) {
+	for msgValue.Kind() == reflect.Ptr {
+		if msgValue.IsNil() {
+			return nil, fmt.Errorf("gotbot: msg is a nil %s", msgValue.Type())
+		}
+
+		msgValue = msgValue.Elem()
+	}
 
-				value = string(js)
-			default:
-				value = fmt.Sprintf("%v", msgValue.Field(i).Interface())
+	if msgValue.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("gotbot: msg must be a struct, got %s", msgValue.Kind())
+	}
+
+	msgType := msgValue.Type()
+	var fields []jsonField
+
+	for i := 0; i < msgValue.NumField(); i++ {
+		structField := msgType.Field(i)
+		if structField.PkgPath != "" {
+			continue // unexported
+		}
+
+		tag := structField.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+
+		name, opts, _ := strings.Cut(tag, ",")
+		fieldValue := msgValue.Field(i)
+
+		if structField.Anonymous && name == "" && isEmbeddableStruct(fieldValue) {
+			if fieldValue.Kind() == reflect.Ptr && fieldValue.IsNil() {
+				continue
 			}
 
-			if err := writer.WriteField(
-				fieldName,
-				value); err != nil {
-				return nil, BodyOptions{}, err
+			nested, err := collectJSONFields(fieldValue)
+			if err != nil {
+				return nil, err
 			}
+
+			fields = append(fields, nested...)
+			continue
 		}
 
-		for i := 0; i < len(files); i++ {
-			if err := func() error {
-				file, err := os.Open(files[i].Path)
-				if err != nil {
-					return err
-				}
-				defer func(file *os.File) {
-					_ = file.Close()
-				}(file)
+		if name == "" {
+			name = structField.Name
+		}
 
-				fileField, err := writer.CreateFormFile(files[i].Name, filepath.Base(files[i].Path))
-				if err != nil {
-					return err
-				}
-				_, err = io.Copy(fileField, file)
+		if strings.Contains(opts, "omitempty") && isEmptyJSONValue(fieldValue) {
+			continue
+		}
 
-				return err
-			}(); err != nil {
-				return nil, BodyOptions{}, err
-			}
+		fields = append(fields, jsonField{name: name, value: fieldValue})
+	}
+
+	return fields, nil
+}
+
+// isEmbeddableStruct reports whether fieldValue is a struct, or a
+// (possibly nil) pointer to one, and so should have its fields promoted
+// to the parent the way encoding/json flattens an anonymous embed.
+func isEmbeddableStruct(fieldValue reflect.Value) bool {
+	if fieldValue.Kind() == reflect.Ptr {
+		return fieldValue.Type().Elem().Kind() == reflect.Struct
+	}
+
+	return fieldValue.Kind() == reflect.Struct
+}
+
+// isEmptyJSONValue mirrors encoding/json's definition of "empty" for
+// ,omitempty: false, 0, a nil pointer/interface/slice/map/channel/func, or
+// a zero-length array/slice/map/string.
+func isEmptyJSONValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Ptr, reflect.Chan, reflect.Func:
+		return v.IsNil()
+	default:
+		return false
+	}
+}
+
+// marshalFieldValue renders v the way Telegram's Bot API expects a
+// multipart form field: everything is funneled through json.Marshal (so
+// pointers are dereferenced, time.Time and custom json.Marshaler types
+// like a ParseMode enum serialize the way they would in a JSON body, and
+// nested ,omitempty is respected), then strings have their surrounding
+// quotes stripped while everything else - numbers, bools, objects, arrays
+// - is written verbatim.
+func marshalFieldValue(v reflect.Value) (string, error) {
+	js, err := json.Marshal(v.Interface())
+	if err != nil {
+		return "", err
+	}
+
+	var s string
+	if err := json.Unmarshal(js, &s); err == nil {
+		return s, nil
+	}
+
+	return string(js), nil
+}
+
+// writeMultipartFiles streams every file in files into writer as its own
+// part, using each envelop's source (Path, Reader, ReadCloser or Bytes, in
+// that order) and advertising its ContentType when set.
+func writeMultipartFiles(writer *multipart.Writer, files []entity.FileEnvelop) error {
+	for i := range files {
+		if err := writeMultipartFile(writer, files[i]); err != nil {
+			return err
 		}
+	}
 
-		return body, BodyOptions{ContentType: writer.FormDataContentType()}, writer.Close()
+	return nil
+}
+
+func writeMultipartFile(writer *multipart.Writer, file entity.FileEnvelop) error {
+	source, fileName, err := openFileSource(file)
+	if err != nil {
+		return err
 	}
-)
+	defer func() {
+		_ = source.Close()
+	}()
+
+	header := make(textproto.MIMEHeader)
+	header.Set("Content-Disposition", fmt.Sprintf(
+		`form-data; name="%s"; filename="%s"`, file.Name, fileName))
+
+	contentType := coalesce(file.ContentType, "application/octet-stream")
+	header.Set("Content-Type", contentType)
+
+	part, err := writer.CreatePart(header)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(part, source)
+	return err
+}
+
+// openFileSource resolves a FileEnvelop's content source - Path, Reader,
+// ReadCloser or Bytes, in that order - into an io.ReadCloser, along with
+// the filename to report to Telegram.
+func openFileSource(file entity.FileEnvelop) (io.ReadCloser, string, error) {
+	fileName := coalesce(file.FileName, filepath.Base(file.Path))
+
+	switch {
+	case file.Path != "":
+		f, err := os.Open(file.Path)
+		if err != nil {
+			return nil, "", err
+		}
+
+		return f, fileName, nil
+	case file.Reader != nil:
+		return io.NopCloser(file.Reader), fileName, nil
+	case file.ReadCloser != nil:
+		return file.ReadCloser, fileName, nil
+	default:
+		return io.NopCloser(bytes.NewReader(file.Bytes)), fileName, nil
+	}
+}
 
 type BodyOptions struct {
 	ContentType string
+	// Replayable reports whether calling the owning BodyFactory again
+	// reproduces the same body. It is false for a multipart body backed
+	// by a file's bare Reader/ReadCloser, which can only be drained once;
+	// retry middlewares must not call the BodyFactory again when this is
+	// false, since doing so would silently resend an empty part.
+	Replayable bool
 }
 
 type MessageType string
@@ -138,6 +388,8 @@ const (
 	// MessageVideoNote is for rounded, 1 minute MPEG4 videos.
 	MessageVideoNote = "sendVideoNote"
 	// MessageMediaGroup is for a group of media messages including photos, audios, videos, documents.
+	// Uploaded files are referenced from the JSON media array via the
+	// "attach://<name>" scheme; see entity.NewMediaGroup and entity.Attach.
 	MessageMediaGroup = "sendMediaGroup"
 	// MessageLocation is for  location message.
 	MessageLocation = "sendLocation"
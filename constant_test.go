@@ -0,0 +1,176 @@
+package gotbot
+
+import (
+	"io"
+	"mime"
+	"mime/multipart"
+	"testing"
+	"time"
+
+	"github.com/roskee/gotbot/entity"
+)
+
+// readMultipartFields drains a GetMultipartBody BodyFactory's output and
+// returns the plain form fields it wrote (file parts are skipped).
+func readMultipartFields(t *testing.T, body BodyFactory) map[string]string {
+	t.Helper()
+
+	r, opts, err := body()
+	if err != nil {
+		t.Fatalf("body(): %v", err)
+	}
+
+	_, params, err := mime.ParseMediaType(opts.ContentType)
+	if err != nil {
+		t.Fatalf("mime.ParseMediaType(%q): %v", opts.ContentType, err)
+	}
+
+	reader := multipart.NewReader(r, params["boundary"])
+	fields := map[string]string{}
+
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("reader.NextPart(): %v", err)
+		}
+
+		if part.FileName() != "" {
+			continue
+		}
+
+		value, err := io.ReadAll(part)
+		if err != nil {
+			t.Fatalf("reading part %q: %v", part.FormName(), err)
+		}
+
+		fields[part.FormName()] = string(value)
+	}
+
+	return fields
+}
+
+func TestGetMultipartBodyPointerField(t *testing.T) {
+	chatID := int64(12345)
+	msg := struct {
+		ChatID *int64 `json:"chat_id"`
+	}{ChatID: &chatID}
+
+	fields := readMultipartFields(t, GetMultipartBody(msg))
+
+	if got, want := fields["chat_id"], "12345"; got != want {
+		t.Errorf("chat_id = %q, want %q", got, want)
+	}
+}
+
+func TestGetMultipartBodyTimeDuration(t *testing.T) {
+	msg := struct {
+		Timeout time.Duration `json:"timeout"`
+	}{Timeout: 30 * time.Second}
+
+	fields := readMultipartFields(t, GetMultipartBody(msg))
+
+	if got, want := fields["timeout"], "30000000000"; got != want {
+		t.Errorf("timeout = %q, want %q", got, want)
+	}
+}
+
+func TestGetMultipartBodyCustomMarshalJSON(t *testing.T) {
+	msg := struct {
+		ParseMode entity.ParseMode `json:"parse_mode"`
+	}{ParseMode: entity.ParseModeMarkdownV2}
+
+	fields := readMultipartFields(t, GetMultipartBody(msg))
+
+	if got, want := fields["parse_mode"], "MarkdownV2"; got != want {
+		t.Errorf("parse_mode = %q, want %q", got, want)
+	}
+}
+
+func TestGetMultipartBodyEmbeddedStruct(t *testing.T) {
+	type Base struct {
+		ChatID int64 `json:"chat_id"`
+	}
+
+	msg := struct {
+		Base
+		Text string `json:"text"`
+	}{Base: Base{ChatID: 42}, Text: "hello"}
+
+	fields := readMultipartFields(t, GetMultipartBody(msg))
+
+	if got, want := fields["chat_id"], "42"; got != want {
+		t.Errorf("chat_id = %q, want %q", got, want)
+	}
+
+	if got, want := fields["text"], "hello"; got != want {
+		t.Errorf("text = %q, want %q", got, want)
+	}
+}
+
+func TestGetMultipartBodyOmitemptyPointer(t *testing.T) {
+	msg := struct {
+		ReplyToMessageID *int64 `json:"reply_to_message_id,omitempty"`
+	}{}
+
+	fields := readMultipartFields(t, GetMultipartBody(msg))
+
+	if _, ok := fields["reply_to_message_id"]; ok {
+		t.Errorf("reply_to_message_id should have been omitted, got %q", fields["reply_to_message_id"])
+	}
+}
+
+func TestGetMultipartBodyEmbeddedPointerStruct(t *testing.T) {
+	type Base struct {
+		ChatID int64 `json:"chat_id"`
+	}
+
+	msg := struct {
+		*Base
+		Text string `json:"text"`
+	}{Base: &Base{ChatID: 42}, Text: "hello"}
+
+	fields := readMultipartFields(t, GetMultipartBody(msg))
+
+	if got, want := fields["chat_id"], "42"; got != want {
+		t.Errorf("chat_id = %q, want %q", got, want)
+	}
+
+	if got, want := fields["text"], "hello"; got != want {
+		t.Errorf("text = %q, want %q", got, want)
+	}
+}
+
+func TestGetMultipartBodyNilEmbeddedPointerStruct(t *testing.T) {
+	type Base struct {
+		ChatID int64 `json:"chat_id"`
+	}
+
+	msg := struct {
+		*Base
+		Text string `json:"text"`
+	}{Text: "hello"}
+
+	fields := readMultipartFields(t, GetMultipartBody(msg))
+
+	if got, want := fields["text"], "hello"; got != want {
+		t.Errorf("text = %q, want %q", got, want)
+	}
+
+	if _, ok := fields["chat_id"]; ok {
+		t.Errorf("chat_id should have been omitted for a nil embedded pointer, got %q", fields["chat_id"])
+	}
+}
+
+func TestGetMultipartBodyRejectsNonStruct(t *testing.T) {
+	r, _, err := GetMultipartBody(map[string]any{"chat_id": 1})()
+	if err != nil {
+		t.Fatalf("body(): %v", err)
+	}
+
+	if _, err := io.ReadAll(r); err == nil {
+		t.Error("expected reading the pipe to surface an error for a non-struct msg")
+	}
+}
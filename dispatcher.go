@@ -0,0 +1,180 @@
+package gotbot
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/roskee/gotbot/entity"
+)
+
+// Context carries one incoming Update through a Handler, along with the
+// Bot that received it and helpers for responding to it.
+type Context struct {
+	context.Context
+	Bot *Bot
+
+	// Update is the raw update being dispatched.
+	Update *entity.Update
+	// Message is Update.Message, set for every update kind except
+	// callback queries.
+	Message *entity.Message
+	// Payload is the text following a "/cmd" or "/cmd@botname", set only
+	// inside a handler registered via OnCommand.
+	Payload string
+}
+
+// Reply sends a text message back to the chat the update's message came
+// from.
+func (c *Context) Reply(text string) error {
+	if c.Message == nil {
+		return fmt.Errorf("gotbot: Context.Reply: no message to reply to")
+	}
+
+	return c.Bot.Do(c, SetApplicationJSON, string(MessageText), GetJSONBody(map[string]any{
+		"chat_id": c.Message.Chat.ID,
+		"text":    text,
+	}), nil)
+}
+
+// Answer answers the callback query carried by this update.
+func (c *Context) Answer(text string) error {
+	if c.Update.CallbackQuery == nil {
+		return fmt.Errorf("gotbot: Context.Answer: no callback query to answer")
+	}
+
+	return c.Bot.Do(c, SetApplicationJSON, "answerCallbackQuery", GetJSONBody(map[string]any{
+		"callback_query_id": c.Update.CallbackQuery.ID,
+		"text":              text,
+	}), nil)
+}
+
+// Handler processes one dispatched Context.
+type Handler func(*Context) error
+
+// Dispatcher routes incoming updates to typed Handlers registered via
+// OnText, OnCommand and friends. The zero Dispatcher is ready to use.
+type Dispatcher struct {
+	// Username, when set, restricts OnCommand handlers to commands either
+	// addressed to "@Username" or with no bot-name suffix at all.
+	Username string
+
+	onText     []Handler
+	onPhoto    []Handler
+	onContact  []Handler
+	onLocation []Handler
+	onVenue    []Handler
+	onCallback []Handler
+	commands   map[string][]Handler
+}
+
+// NewDispatcher creates a Dispatcher for a bot with the given username
+// (used to validate "/cmd@username" commands; pass "" to accept any).
+func NewDispatcher(username string) *Dispatcher {
+	return &Dispatcher{Username: username, commands: map[string][]Handler{}}
+}
+
+// OnText registers h for text messages that aren't commands for this bot; a
+// command addressed to a different bot's username is routed here as text.
+func (d *Dispatcher) OnText(h Handler) { d.onText = append(d.onText, h) }
+
+// OnPhoto registers h for messages carrying a photo.
+func (d *Dispatcher) OnPhoto(h Handler) { d.onPhoto = append(d.onPhoto, h) }
+
+// OnContact registers h for messages sharing a contact.
+func (d *Dispatcher) OnContact(h Handler) { d.onContact = append(d.onContact, h) }
+
+// OnLocation registers h for messages sharing a location.
+func (d *Dispatcher) OnLocation(h Handler) { d.onLocation = append(d.onLocation, h) }
+
+// OnVenue registers h for messages sharing a venue.
+func (d *Dispatcher) OnVenue(h Handler) { d.onVenue = append(d.onVenue, h) }
+
+// OnCallback registers h for inline keyboard callback queries.
+func (d *Dispatcher) OnCallback(h Handler) { d.onCallback = append(d.onCallback, h) }
+
+// OnCommand registers h for messages starting with "/cmd" or
+// "/cmd@username" (see Dispatcher.Username). cmd is given without its
+// leading slash, e.g. "start".
+func (d *Dispatcher) OnCommand(cmd string, h Handler) {
+	cmd = strings.TrimPrefix(cmd, "/")
+	if d.commands == nil {
+		d.commands = map[string][]Handler{}
+	}
+	d.commands[cmd] = append(d.commands[cmd], h)
+}
+
+// Dispatch routes update to every Handler registered for its kind.
+func (d *Dispatcher) Dispatch(ctx *Context) error {
+	if cq := ctx.Update.CallbackQuery; cq != nil {
+		if cq.Message != nil {
+			ctx.Context = WithChatID(ctx.Context, cq.Message.Chat.ID)
+		}
+		return runHandlers(d.onCallback, ctx)
+	}
+
+	msg := ctx.Update.Message
+	if msg == nil {
+		return nil
+	}
+
+	ctx.Message = msg
+	ctx.Context = WithChatID(ctx.Context, msg.Chat.ID)
+
+	if cmd, payload, ok := parseCommand(msg.Text, d.Username); ok {
+		ctx.Payload = payload
+		return runHandlers(d.commands[cmd], ctx)
+	}
+
+	switch {
+	case msg.Text != "":
+		return runHandlers(d.onText, ctx)
+	case len(msg.Photo) > 0:
+		return runHandlers(d.onPhoto, ctx)
+	case msg.Contact != nil:
+		return runHandlers(d.onContact, ctx)
+	case msg.Venue != nil:
+		return runHandlers(d.onVenue, ctx)
+	case msg.Location != nil:
+		return runHandlers(d.onLocation, ctx)
+	}
+
+	return nil
+}
+
+func runHandlers(handlers []Handler, ctx *Context) error {
+	for _, h := range handlers {
+		if err := h(ctx); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// parseCommand parses Telegram's "/cmd@botname payload" command syntax. ok
+// is false when text isn't a command, or is addressed to a bot other than
+// username (when username is non-empty).
+func parseCommand(text, username string) (cmd, payload string, ok bool) {
+	if !strings.HasPrefix(text, "/") {
+		return "", "", false
+	}
+
+	fields := strings.SplitN(text[1:], " ", 2)
+	if len(fields) == 2 {
+		payload = fields[1]
+	}
+
+	cmd = fields[0]
+
+	if at := strings.IndexByte(cmd, '@'); at >= 0 {
+		botName := cmd[at+1:]
+		cmd = cmd[:at]
+
+		if username != "" && !strings.EqualFold(botName, username) {
+			return "", "", false
+		}
+	}
+
+	return cmd, payload, true
+}
@@ -0,0 +1,207 @@
+package gotbot
+
+import (
+	"context"
+	"testing"
+
+	"github.com/roskee/gotbot/entity"
+)
+
+func TestParseCommand(t *testing.T) {
+	tests := []struct {
+		name        string
+		text        string
+		username    string
+		wantCmd     string
+		wantPayload string
+		wantOK      bool
+	}{
+		{name: "not a command", text: "hello there", username: "", wantOK: false},
+		{name: "plain command", text: "/start", username: "", wantCmd: "start", wantOK: true},
+		{name: "command with payload", text: "/start ref123", username: "", wantCmd: "start", wantPayload: "ref123", wantOK: true},
+		{name: "command with matching bot name", text: "/start@mybot ref123", username: "mybot", wantCmd: "start", wantPayload: "ref123", wantOK: true},
+		{name: "command with bot name, case-insensitive", text: "/start@MyBot", username: "mybot", wantCmd: "start", wantOK: true},
+		{name: "command addressed to a different bot", text: "/start@otherbot", username: "mybot", wantOK: false},
+		{name: "command with bot name, no username configured", text: "/start@anybot", username: "", wantCmd: "start", wantOK: true},
+		{name: "empty text", text: "", username: "", wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd, payload, ok := parseCommand(tt.text, tt.username)
+
+			if ok != tt.wantOK {
+				t.Fatalf("ok = %v, want %v", ok, tt.wantOK)
+			}
+
+			if !ok {
+				return
+			}
+
+			if cmd != tt.wantCmd {
+				t.Errorf("cmd = %q, want %q", cmd, tt.wantCmd)
+			}
+
+			if payload != tt.wantPayload {
+				t.Errorf("payload = %q, want %q", payload, tt.wantPayload)
+			}
+		})
+	}
+}
+
+func TestDispatchRouting(t *testing.T) {
+	newCtx := func(msg *entity.Message) *Context {
+		return &Context{Context: context.Background(), Update: &entity.Update{Message: msg}}
+	}
+
+	t.Run("text", func(t *testing.T) {
+		var got bool
+		d := NewDispatcher("")
+		d.OnText(func(*Context) error { got = true; return nil })
+
+		if err := d.Dispatch(newCtx(&entity.Message{Text: "hello"})); err != nil {
+			t.Fatalf("Dispatch: %v", err)
+		}
+
+		if !got {
+			t.Error("OnText handler wasn't called")
+		}
+	})
+
+	t.Run("command takes priority over text", func(t *testing.T) {
+		var gotText, gotCommand bool
+		d := NewDispatcher("")
+		d.OnText(func(*Context) error { gotText = true; return nil })
+		d.OnCommand("start", func(ctx *Context) error {
+			gotCommand = true
+			if ctx.Payload != "ref" {
+				t.Errorf("Payload = %q, want %q", ctx.Payload, "ref")
+			}
+			return nil
+		})
+
+		if err := d.Dispatch(newCtx(&entity.Message{Text: "/start ref"})); err != nil {
+			t.Fatalf("Dispatch: %v", err)
+		}
+
+		if !gotCommand {
+			t.Error("OnCommand handler wasn't called")
+		}
+
+		if gotText {
+			t.Error("OnText handler should not have been called for a command")
+		}
+	})
+
+	t.Run("photo", func(t *testing.T) {
+		var got bool
+		d := NewDispatcher("")
+		d.OnPhoto(func(*Context) error { got = true; return nil })
+
+		if err := d.Dispatch(newCtx(&entity.Message{Photo: []entity.PhotoSize{{FileID: "AAA"}}})); err != nil {
+			t.Fatalf("Dispatch: %v", err)
+		}
+
+		if !got {
+			t.Error("OnPhoto handler wasn't called")
+		}
+	})
+
+	t.Run("contact", func(t *testing.T) {
+		var got bool
+		d := NewDispatcher("")
+		d.OnContact(func(*Context) error { got = true; return nil })
+
+		if err := d.Dispatch(newCtx(&entity.Message{Contact: &entity.Contact{PhoneNumber: "+100"}})); err != nil {
+			t.Fatalf("Dispatch: %v", err)
+		}
+
+		if !got {
+			t.Error("OnContact handler wasn't called")
+		}
+	})
+
+	t.Run("location", func(t *testing.T) {
+		var got bool
+		d := NewDispatcher("")
+		d.OnLocation(func(*Context) error { got = true; return nil })
+
+		if err := d.Dispatch(newCtx(&entity.Message{Location: &entity.Location{Latitude: 1, Longitude: 2}})); err != nil {
+			t.Fatalf("Dispatch: %v", err)
+		}
+
+		if !got {
+			t.Error("OnLocation handler wasn't called")
+		}
+	})
+
+	t.Run("venue", func(t *testing.T) {
+		var got bool
+		d := NewDispatcher("")
+		d.OnVenue(func(*Context) error { got = true; return nil })
+
+		if err := d.Dispatch(newCtx(&entity.Message{Venue: &entity.Venue{Title: "Cafe"}})); err != nil {
+			t.Fatalf("Dispatch: %v", err)
+		}
+
+		if !got {
+			t.Error("OnVenue handler wasn't called")
+		}
+	})
+
+	t.Run("callback query", func(t *testing.T) {
+		var got bool
+		d := NewDispatcher("")
+		d.OnCallback(func(*Context) error { got = true; return nil })
+
+		ctx := &Context{Context: context.Background(), Update: &entity.Update{CallbackQuery: &entity.CallbackQuery{ID: "1", Data: "x"}}}
+		if err := d.Dispatch(ctx); err != nil {
+			t.Fatalf("Dispatch: %v", err)
+		}
+
+		if !got {
+			t.Error("OnCallback handler wasn't called")
+		}
+	})
+
+	t.Run("message attaches chat ID for rate limiting", func(t *testing.T) {
+		var gotChatID int64
+		var gotOK bool
+		d := NewDispatcher("")
+		d.OnText(func(ctx *Context) error {
+			gotChatID, gotOK = chatIDFromContext(ctx.Context)
+			return nil
+		})
+
+		ctx := newCtx(&entity.Message{Text: "hi", Chat: entity.Chat{ID: 42}})
+		if err := d.Dispatch(ctx); err != nil {
+			t.Fatalf("Dispatch: %v", err)
+		}
+
+		if !gotOK || gotChatID != 42 {
+			t.Errorf("chatIDFromContext = (%d, %v), want (42, true)", gotChatID, gotOK)
+		}
+	})
+
+	t.Run("callback query attaches chat ID from its message", func(t *testing.T) {
+		var gotChatID int64
+		var gotOK bool
+		d := NewDispatcher("")
+		d.OnCallback(func(ctx *Context) error {
+			gotChatID, gotOK = chatIDFromContext(ctx.Context)
+			return nil
+		})
+
+		ctx := &Context{Context: context.Background(), Update: &entity.Update{CallbackQuery: &entity.CallbackQuery{
+			ID:      "1",
+			Message: &entity.Message{Chat: entity.Chat{ID: 99}},
+		}}}
+		if err := d.Dispatch(ctx); err != nil {
+			t.Fatalf("Dispatch: %v", err)
+		}
+
+		if !gotOK || gotChatID != 99 {
+			t.Errorf("chatIDFromContext = (%d, %v), want (99, true)", gotChatID, gotOK)
+		}
+	})
+}
@@ -0,0 +1,36 @@
+package entity
+
+import "io"
+
+// FileEnvelop represents a file to be attached to a multipart request.
+//
+// Exactly one of Path, Reader, ReadCloser or Bytes should be set as the
+// source of the file's content; they are tried in that order. ContentType
+// and FileName are optional and, when empty, are defaulted (FileName to
+// the base of Path, ContentType to "application/octet-stream").
+type FileEnvelop struct {
+	// Name is the multipart form field name the file is attached under.
+	Name string
+	// Path is the path to a file on disk to stream from.
+	Path string
+	// Reader, when set and Path is empty, is streamed directly.
+	Reader io.Reader
+	// ReadCloser, when set, is streamed and closed once fully read.
+	ReadCloser io.ReadCloser
+	// Bytes, when set, is used verbatim as the file's content.
+	Bytes []byte
+	// ContentType is the MIME type advertised for this part, e.g. "image/jpeg".
+	ContentType string
+	// FileName is the filename reported to Telegram. Defaults to
+	// filepath.Base(Path) when Path is set and FileName is empty.
+	FileName string
+}
+
+// Replayable reports whether f's content source can be read more than
+// once - true for Path (re-opened) and Bytes (reused verbatim), false for
+// a bare Reader/ReadCloser, which can only be drained once. Callers that
+// need to retry a request should not resubmit a non-replayable
+// FileEnvelop: doing so silently re-sends an empty (already-drained) part.
+func (f FileEnvelop) Replayable() bool {
+	return f.Reader == nil && f.ReadCloser == nil
+}
@@ -0,0 +1,104 @@
+package entity
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// inputFileKind identifies which of Telegram's three ways to reference
+// media an InputFile holds.
+type inputFileKind int
+
+const (
+	inputFileIDKind inputFileKind = iota
+	inputFileURLKind
+	inputFileUploadKind
+)
+
+// InputFile unifies the three ways Telegram accepts media: an existing
+// file_id, an HTTPS URL it fetches itself, or content gotbot must upload
+// from a local path, an in-memory byte slice, or a stream. Build one with
+// FromFileID, FromURL, FromPath, FromReader or FromBytes.
+type InputFile struct {
+	kind  inputFileKind
+	value string // the file_id or URL, for inputFileIDKind/inputFileURLKind
+	file  FileEnvelop
+}
+
+// FromFileID references an existing Telegram file_id.
+func FromFileID(id string) InputFile {
+	return InputFile{kind: inputFileIDKind, value: id}
+}
+
+// FromURL references an HTTPS URL Telegram fetches itself.
+func FromURL(url string) InputFile {
+	return InputFile{kind: inputFileURLKind, value: url}
+}
+
+// FromPath uploads the file at path.
+func FromPath(path string) InputFile {
+	return InputFile{kind: inputFileUploadKind, file: FileEnvelop{Path: path}}
+}
+
+// FromReader uploads from r, reporting name as its filename.
+func FromReader(name string, r io.Reader) InputFile {
+	return InputFile{kind: inputFileUploadKind, file: FileEnvelop{Reader: r, FileName: name}}
+}
+
+// FromBytes uploads b verbatim, reporting name as its filename and
+// contentType as its Content-Type.
+func FromBytes(name string, b []byte, contentType string) InputFile {
+	return InputFile{kind: inputFileUploadKind, file: FileEnvelop{Bytes: b, FileName: name, ContentType: contentType}}
+}
+
+// NeedsUpload reports whether f holds local/stream content that must be
+// sent as a multipart file, as opposed to a file_id or URL Telegram can
+// resolve from a bare JSON string.
+func (f InputFile) NeedsUpload() bool {
+	return f.kind == inputFileUploadKind
+}
+
+// Envelop returns the FileEnvelop to upload f under the multipart form
+// field name. It panics if f doesn't NeedsUpload; callers should check
+// that first.
+func (f InputFile) Envelop(name string) FileEnvelop {
+	if !f.NeedsUpload() {
+		panic("gotbot: entity.InputFile.Envelop called on a file_id/URL source")
+	}
+
+	env := f.file
+	env.Name = name
+
+	return env
+}
+
+// Value returns the bare file_id or URL string f holds. It panics if f
+// NeedsUpload, since an upload-backed InputFile has no such string form;
+// callers should route those through Envelop instead.
+func (f InputFile) Value() string {
+	if f.NeedsUpload() {
+		panic("gotbot: entity.InputFile.Value called on a local/stream source")
+	}
+
+	return f.value
+}
+
+// Replayable reports whether f's content can be read more than once - see
+// FileEnvelop.Replayable. A file_id/URL source is always replayable, since
+// it is just a string.
+func (f InputFile) Replayable() bool {
+	return !f.NeedsUpload() || f.file.Replayable()
+}
+
+// MarshalJSON implements json.Marshaler, emitting f as the bare file_id or
+// URL string Telegram expects. It errors for a local/stream source, which
+// has no JSON representation and must instead be sent via GetMultipartBody
+// (or GetBody, which switches automatically).
+func (f InputFile) MarshalJSON() ([]byte, error) {
+	if f.NeedsUpload() {
+		return nil, fmt.Errorf("gotbot: entity.InputFile holding a local/stream source can't be marshaled to JSON; use GetMultipartBody or GetBody instead")
+	}
+
+	return json.Marshal(f.value)
+}
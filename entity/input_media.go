@@ -0,0 +1,78 @@
+package entity
+
+import "fmt"
+
+// InputMedia describes one item of a media group (sendMediaGroup) or a
+// message thumbnail. Media and Thumb hold the file_id, HTTPS URL, or
+// "attach://<name>" placeholder Telegram expects - see NewMediaGroup to
+// build these from InputFile sources.
+type InputMedia struct {
+	Type      string    `json:"type"`
+	Media     string    `json:"media"`
+	Thumb     string    `json:"thumb,omitempty"`
+	Caption   string    `json:"caption,omitempty"`
+	ParseMode ParseMode `json:"parse_mode,omitempty"`
+}
+
+// MediaGroupItem is one photo/audio/video/document to include in a media
+// group. Media and Thumb can each be a file_id, an HTTPS URL, or a
+// local/stream source to upload - see InputFile.
+type MediaGroupItem struct {
+	Type      string
+	Caption   string
+	ParseMode ParseMode
+	Media     InputFile
+	Thumb     *InputFile
+}
+
+// Attach assigns name as file's form field name and returns the
+// "attach://<name>" placeholder that should be used in place of the field
+// (e.g. InputMedia.Media, or a sendVideo/sendAudio/sendDocument Thumb
+// field) referring to it, alongside the now-named FileEnvelop to pass to
+// GetMultipartBody.
+func Attach(name string, file FileEnvelop) (string, FileEnvelop) {
+	file.Name = name
+	return "attach://" + name, file
+}
+
+// attachMediaRef resolves ref to the string InputMedia.Media/Thumb
+// expects: the bare file_id/URL when ref doesn't need uploading, or an
+// "attach://<name>" placeholder when it does, collecting the upload's
+// FileEnvelop into files.
+func attachMediaRef(name string, ref InputFile, files *[]FileEnvelop) string {
+	if !ref.NeedsUpload() {
+		return ref.Value()
+	}
+
+	attachRef, file := Attach(name, ref.Envelop(name))
+	*files = append(*files, file)
+
+	return attachRef
+}
+
+// NewMediaGroup turns items into the InputMedia slice Telegram expects for
+// sendMediaGroup, together with the FileEnvelops to upload alongside it.
+// Only Media/Thumb sources that InputFile.NeedsUpload get collected as a
+// FileEnvelop and an auto-generated "attach://fileN" / "attach://thumbN"
+// placeholder; a file_id or URL source is emitted directly with no upload.
+func NewMediaGroup(items ...MediaGroupItem) ([]InputMedia, []FileEnvelop) {
+	media := make([]InputMedia, len(items))
+	var files []FileEnvelop
+
+	for i, item := range items {
+		m := InputMedia{
+			Type:      item.Type,
+			Media:     attachMediaRef(fmt.Sprintf("file%d", i), item.Media, &files),
+			Caption:   item.Caption,
+			ParseMode: item.ParseMode,
+		}
+
+		if item.Thumb != nil {
+			m.Thumb = attachMediaRef(fmt.Sprintf("thumb%d", i), *item.Thumb, &files)
+		}
+
+		media[i] = m
+	}
+
+	return media, files
+}
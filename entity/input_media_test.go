@@ -0,0 +1,101 @@
+package entity
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestNewMediaGroupFileIDAndURL(t *testing.T) {
+	media, files := NewMediaGroup(
+		MediaGroupItem{Type: "photo", Media: FromFileID("AAA")},
+		MediaGroupItem{Type: "photo", Media: FromURL("https://example.com/cat.jpg")},
+	)
+
+	if len(files) != 0 {
+		t.Fatalf("files = %v, want none for file_id/URL sources", files)
+	}
+
+	if got, want := media[0].Media, "AAA"; got != want {
+		t.Errorf("media[0].Media = %q, want %q", got, want)
+	}
+
+	if got, want := media[1].Media, "https://example.com/cat.jpg"; got != want {
+		t.Errorf("media[1].Media = %q, want %q", got, want)
+	}
+}
+
+func TestNewMediaGroupUpload(t *testing.T) {
+	thumb := FromBytes("thumb.jpg", []byte("thumb-data"), "image/jpeg")
+
+	media, files := NewMediaGroup(
+		MediaGroupItem{
+			Type:  "video",
+			Media: FromBytes("video.mp4", []byte("video-data"), "video/mp4"),
+			Thumb: &thumb,
+		},
+	)
+
+	if got, want := media[0].Media, "attach://file0"; got != want {
+		t.Errorf("media[0].Media = %q, want %q", got, want)
+	}
+
+	if got, want := media[0].Thumb, "attach://thumb0"; got != want {
+		t.Errorf("media[0].Thumb = %q, want %q", got, want)
+	}
+
+	if len(files) != 2 {
+		t.Fatalf("files = %d, want 2", len(files))
+	}
+
+	if files[0].Name != "file0" || files[1].Name != "thumb0" {
+		t.Errorf("files = %+v, want names file0 and thumb0", files)
+	}
+}
+
+func TestNewMediaGroupMixed(t *testing.T) {
+	media, files := NewMediaGroup(
+		MediaGroupItem{Type: "photo", Media: FromFileID("AAA")},
+		MediaGroupItem{Type: "photo", Media: FromBytes("photo.jpg", []byte("data"), "image/jpeg")},
+	)
+
+	if media[0].Media != "AAA" {
+		t.Errorf("media[0].Media = %q, want %q", media[0].Media, "AAA")
+	}
+
+	if media[1].Media != "attach://file1" {
+		t.Errorf("media[1].Media = %q, want %q", media[1].Media, "attach://file1")
+	}
+
+	if len(files) != 1 || files[0].Name != "file1" {
+		t.Errorf("files = %+v, want one file named file1", files)
+	}
+}
+
+func TestNewMediaGroupParseMode(t *testing.T) {
+	media, _ := NewMediaGroup(
+		MediaGroupItem{Type: "photo", Media: FromFileID("AAA"), ParseMode: ParseModeMarkdownV2},
+	)
+
+	body, err := json.Marshal(media[0])
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+
+	if got, want := string(body), `"parse_mode":"MarkdownV2"`; !strings.Contains(got, want) {
+		t.Errorf("marshalled %s, want it to contain %s", got, want)
+	}
+}
+
+func TestNewMediaGroupParseModeNoneOmitted(t *testing.T) {
+	media, _ := NewMediaGroup(MediaGroupItem{Type: "photo", Media: FromFileID("AAA")})
+
+	body, err := json.Marshal(media[0])
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+
+	if strings.Contains(string(body), "parse_mode") {
+		t.Errorf("marshalled %s, want parse_mode omitted for ParseModeNone", body)
+	}
+}
@@ -0,0 +1,34 @@
+package entity
+
+import "encoding/json"
+
+// ParseMode selects how Telegram parses a message's text for formatting
+// entities. It marshals to Telegram's own string constants rather than its
+// underlying int value.
+type ParseMode int
+
+const (
+	ParseModeNone ParseMode = iota
+	ParseModeMarkdown
+	ParseModeMarkdownV2
+	ParseModeHTML
+)
+
+func (p ParseMode) String() string {
+	switch p {
+	case ParseModeMarkdown:
+		return "Markdown"
+	case ParseModeMarkdownV2:
+		return "MarkdownV2"
+	case ParseModeHTML:
+		return "HTML"
+	default:
+		return ""
+	}
+}
+
+// MarshalJSON implements json.Marshaler so ParseMode serializes as the
+// string Telegram expects instead of its underlying int value.
+func (p ParseMode) MarshalJSON() ([]byte, error) {
+	return json.Marshal(p.String())
+}
@@ -0,0 +1,72 @@
+package entity
+
+// Update represents an incoming Telegram update, as delivered by both
+// getUpdates (long polling) and a webhook callback. Only the update kinds
+// gotbot's dispatcher currently routes on are modeled.
+type Update struct {
+	UpdateID      int64          `json:"update_id"`
+	Message       *Message       `json:"message,omitempty"`
+	CallbackQuery *CallbackQuery `json:"callback_query,omitempty"`
+}
+
+// Message is a Telegram message, reduced to the fields needed to dispatch
+// on and reply to it.
+type Message struct {
+	MessageID int64       `json:"message_id"`
+	From      *User       `json:"from,omitempty"`
+	Chat      Chat        `json:"chat"`
+	Text      string      `json:"text,omitempty"`
+	Photo     []PhotoSize `json:"photo,omitempty"`
+	Contact   *Contact    `json:"contact,omitempty"`
+	Location  *Location   `json:"location,omitempty"`
+	Venue     *Venue      `json:"venue,omitempty"`
+}
+
+// User is a Telegram user or bot.
+type User struct {
+	ID        int64  `json:"id"`
+	IsBot     bool   `json:"is_bot"`
+	Username  string `json:"username,omitempty"`
+	FirstName string `json:"first_name"`
+}
+
+// Chat is the chat a message belongs to.
+type Chat struct {
+	ID   int64  `json:"id"`
+	Type string `json:"type"`
+}
+
+// PhotoSize is one size variant of a photo message.
+type PhotoSize struct {
+	FileID   string `json:"file_id"`
+	Width    int    `json:"width"`
+	Height   int    `json:"height"`
+	FileSize int    `json:"file_size,omitempty"`
+}
+
+// Contact is a shared phone contact.
+type Contact struct {
+	PhoneNumber string `json:"phone_number"`
+	FirstName   string `json:"first_name"`
+}
+
+// Location is a shared point on the map.
+type Location struct {
+	Longitude float64 `json:"longitude"`
+	Latitude  float64 `json:"latitude"`
+}
+
+// Venue is a shared place with a Location and a name.
+type Venue struct {
+	Location Location `json:"location"`
+	Title    string   `json:"title"`
+	Address  string   `json:"address"`
+}
+
+// CallbackQuery is raised when a user taps an inline keyboard button.
+type CallbackQuery struct {
+	ID      string   `json:"id"`
+	From    User     `json:"from"`
+	Message *Message `json:"message,omitempty"`
+	Data    string   `json:"data,omitempty"`
+}
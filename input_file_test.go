@@ -0,0 +1,155 @@
+package gotbot
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/roskee/gotbot/entity"
+)
+
+func TestGetBodyFileIDUsesJSON(t *testing.T) {
+	msg := struct {
+		Photo entity.InputFile `json:"photo"`
+	}{Photo: entity.FromFileID("AAA")}
+
+	body, err := GetBody(msg)
+	if err != nil {
+		t.Fatalf("GetBody: %v", err)
+	}
+
+	r, opts, err := body()
+	if err != nil {
+		t.Fatalf("body(): %v", err)
+	}
+
+	if opts.ContentType != "application/json" {
+		t.Fatalf("ContentType = %q, want application/json", opts.ContentType)
+	}
+
+	var decoded struct {
+		Photo string `json:"photo"`
+	}
+	if err := json.NewDecoder(r).Decode(&decoded); err != nil {
+		t.Fatalf("decoding json body: %v", err)
+	}
+
+	if decoded.Photo != "AAA" {
+		t.Errorf("photo = %q, want %q", decoded.Photo, "AAA")
+	}
+}
+
+func TestGetBodyUploadSwitchesToMultipart(t *testing.T) {
+	msg := struct {
+		ChatID int64            `json:"chat_id"`
+		Photo  entity.InputFile `json:"photo"`
+	}{ChatID: 1, Photo: entity.FromBytes("photo.jpg", []byte("data"), "image/jpeg")}
+
+	body, err := GetBody(msg)
+	if err != nil {
+		t.Fatalf("GetBody: %v", err)
+	}
+
+	fields := readMultipartFields(t, body)
+
+	if got, want := fields["chat_id"], "1"; got != want {
+		t.Errorf("chat_id = %q, want %q", got, want)
+	}
+}
+
+func TestGetBodyNonNilPointer(t *testing.T) {
+	msg := &struct {
+		Photo entity.InputFile `json:"photo"`
+	}{Photo: entity.FromFileID("AAA")}
+
+	body, err := GetBody(msg)
+	if err != nil {
+		t.Fatalf("GetBody: %v", err)
+	}
+
+	r, opts, err := body()
+	if err != nil {
+		t.Fatalf("body(): %v", err)
+	}
+
+	if opts.ContentType != "application/json" {
+		t.Fatalf("ContentType = %q, want application/json", opts.ContentType)
+	}
+
+	var decoded struct {
+		Photo string `json:"photo"`
+	}
+	if err := json.NewDecoder(r).Decode(&decoded); err != nil {
+		t.Fatalf("decoding json body: %v", err)
+	}
+
+	if decoded.Photo != "AAA" {
+		t.Errorf("photo = %q, want %q", decoded.Photo, "AAA")
+	}
+}
+
+func TestGetBodyUploadThroughPointerFieldSwitchesToMultipart(t *testing.T) {
+	thumb := entity.FromBytes("thumb.jpg", []byte("thumb-data"), "image/jpeg")
+
+	msg := struct {
+		ChatID int64             `json:"chat_id"`
+		Thumb  *entity.InputFile `json:"thumb,omitempty"`
+	}{ChatID: 1, Thumb: &thumb}
+
+	body, err := GetBody(msg)
+	if err != nil {
+		t.Fatalf("GetBody: %v", err)
+	}
+
+	fields := readMultipartFields(t, body)
+
+	if got, want := fields["chat_id"], "1"; got != want {
+		t.Errorf("chat_id = %q, want %q", got, want)
+	}
+}
+
+func TestGetBodyNilPointerFieldIsSkipped(t *testing.T) {
+	msg := struct {
+		ChatID int64             `json:"chat_id"`
+		Thumb  *entity.InputFile `json:"thumb,omitempty"`
+	}{ChatID: 1}
+
+	body, err := GetBody(msg)
+	if err != nil {
+		t.Fatalf("GetBody: %v", err)
+	}
+
+	if _, opts, err := body(); err != nil || opts.ContentType != "application/json" {
+		t.Fatalf("body() = (opts: %+v, err: %v), want a JSON body", opts, err)
+	}
+}
+
+func TestGetBodyNilPointerReturnsError(t *testing.T) {
+	var msg *struct {
+		Photo entity.InputFile `json:"photo"`
+	}
+
+	if _, err := GetBody(msg); err == nil {
+		t.Fatal("expected an error, got nil")
+	}
+}
+
+func TestInputFileMarshalJSONRejectsUpload(t *testing.T) {
+	f := entity.FromPath("video.mp4")
+
+	if _, err := json.Marshal(f); err == nil {
+		t.Fatal("expected an error marshaling an upload-backed InputFile to JSON")
+	}
+}
+
+func TestInputFileMarshalJSONURL(t *testing.T) {
+	f := entity.FromURL("https://example.com/cat.jpg")
+
+	js, err := json.Marshal(f)
+	if err != nil {
+		t.Fatalf("json.Marshal: %v", err)
+	}
+
+	if got, want := string(js), `"https://example.com/cat.jpg"`; got != want {
+		t.Errorf("marshaled = %s, want %s", got, want)
+	}
+}
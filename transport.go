@@ -0,0 +1,231 @@
+package gotbot
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"math"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrBodyNotReplayable is returned by RetryAfterMiddleware and
+// BackoffMiddleware instead of retrying a request whose BodyFactory
+// reported BodyOptions.Replayable == false (e.g. a multipart upload backed
+// by a bare io.Reader/io.ReadCloser): calling the BodyFactory again would
+// silently resend an already-drained, empty file part.
+var ErrBodyNotReplayable = errors.New("gotbot: request body is not replayable; refusing to retry")
+
+// Request is the in-flight request passed through a Bot's middleware
+// chain.
+type Request struct {
+	Ctx    context.Context
+	Method string
+	Setup  func(*http.Request) error
+	Body   BodyFactory
+
+	// LastBodyOptions is the BodyOptions returned by the most recent Body
+	// call, recorded by Bot's innermost RoundTrip. Middlewares deciding
+	// whether to retry should check LastBodyOptions.Replayable before
+	// calling Body (indirectly, via next) again.
+	LastBodyOptions BodyOptions
+}
+
+// RoundTrip sends req and returns Telegram's raw HTTP response.
+type RoundTrip func(req *Request) (*http.Response, error)
+
+// Middleware wraps a RoundTrip to add cross-cutting behaviour - rate
+// limiting, retries, logging - around every Bot.Do call. Middlewares run
+// outermost-first in the order passed to NewBot/Use.
+type Middleware func(next RoundTrip) RoundTrip
+
+type chatIDKey struct{}
+
+// WithChatID attaches a chat ID to ctx so RateLimitMiddleware can key its
+// per-chat token bucket off it. Pass the returned context to Bot.Do.
+func WithChatID(ctx context.Context, chatID int64) context.Context {
+	return context.WithValue(ctx, chatIDKey{}, chatID)
+}
+
+func chatIDFromContext(ctx context.Context) (int64, bool) {
+	chatID, ok := ctx.Value(chatIDKey{}).(int64)
+	return chatID, ok
+}
+
+// tokenBucket is a small dependency-free token-bucket rate limiter.
+type tokenBucket struct {
+	mu     sync.Mutex
+	tokens float64
+	max    float64
+	refill float64 // tokens added per second
+	last   time.Time
+}
+
+func newTokenBucket(refillPerSecond float64) *tokenBucket {
+	// Hold at least one token, so rates below 1/sec still let requests through.
+	capacity := math.Max(1, refillPerSecond)
+	return &tokenBucket{tokens: capacity, max: capacity, refill: refillPerSecond, last: time.Now()}
+}
+
+// wait blocks until a token is available or ctx is done.
+func (b *tokenBucket) wait(ctx context.Context) error {
+	for {
+		b.mu.Lock()
+		now := time.Now()
+		b.tokens = math.Min(b.max, b.tokens+now.Sub(b.last).Seconds()*b.refill)
+		b.last = now
+
+		if b.tokens >= 1 {
+			b.tokens--
+			b.mu.Unlock()
+			return nil
+		}
+
+		wait := time.Duration((1 - b.tokens) / b.refill * float64(time.Second))
+		b.mu.Unlock()
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// RateLimitMiddleware enforces Telegram's flood limits: a global bucket
+// capped at globalPerSecond requests/sec, plus a bucket per chat ID capped
+// at perChatPerSecond requests/sec (Telegram recommends roughly 30/sec
+// overall and 1/sec per chat). Requests block until a token is available
+// or their context is done, rather than failing outright. Requests with no
+// chat ID attached via WithChatID only draw from the global bucket.
+func RateLimitMiddleware(globalPerSecond, perChatPerSecond float64) Middleware {
+	global := newTokenBucket(globalPerSecond)
+
+	var mu sync.Mutex
+	perChat := map[int64]*tokenBucket{}
+
+	return func(next RoundTrip) RoundTrip {
+		return func(req *Request) (*http.Response, error) {
+			if err := global.wait(req.Ctx); err != nil {
+				return nil, err
+			}
+
+			if chatID, ok := chatIDFromContext(req.Ctx); ok {
+				mu.Lock()
+				bucket, found := perChat[chatID]
+				if !found {
+					bucket = newTokenBucket(perChatPerSecond)
+					perChat[chatID] = bucket
+				}
+				mu.Unlock()
+
+				if err := bucket.wait(req.Ctx); err != nil {
+					return nil, err
+				}
+			}
+
+			return next(req)
+		}
+	}
+}
+
+// RetryAfterMiddleware retries a request Telegram rejected with HTTP 429,
+// sleeping for the "parameters.retry_after" seconds reported in the JSON
+// error body (falling back to fallback when absent) before each retry, up
+// to maxRetries times.
+func RetryAfterMiddleware(maxRetries int, fallback time.Duration) Middleware {
+	return func(next RoundTrip) RoundTrip {
+		return func(req *Request) (*http.Response, error) {
+			var resp *http.Response
+			var err error
+
+			for attempt := 0; ; attempt++ {
+				resp, err = next(req)
+				if err != nil || resp.StatusCode != http.StatusTooManyRequests || attempt >= maxRetries {
+					return resp, err
+				}
+
+				if !req.LastBodyOptions.Replayable {
+					_ = resp.Body.Close()
+					return nil, ErrBodyNotReplayable
+				}
+
+				wait := retryAfterFromResponse(resp, fallback)
+				_ = resp.Body.Close()
+
+				timer := time.NewTimer(wait)
+				select {
+				case <-req.Ctx.Done():
+					timer.Stop()
+					return nil, req.Ctx.Err()
+				case <-timer.C:
+				}
+			}
+		}
+	}
+}
+
+func retryAfterFromResponse(resp *http.Response, fallback time.Duration) time.Duration {
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fallback
+	}
+
+	var apiResp apiResponse
+	if err := json.Unmarshal(body, &apiResp); err != nil || apiResp.Parameters == nil || apiResp.Parameters.RetryAfter <= 0 {
+		return fallback
+	}
+
+	return time.Duration(apiResp.Parameters.RetryAfter) * time.Second
+}
+
+// BackoffMiddleware retries on HTTP 5xx responses and network errors with
+// exponential backoff, starting at base and capped at max, up to
+// maxRetries times.
+func BackoffMiddleware(maxRetries int, base, max time.Duration) Middleware {
+	return func(next RoundTrip) RoundTrip {
+		return func(req *Request) (*http.Response, error) {
+			wait := base
+
+			for attempt := 0; ; attempt++ {
+				resp, err := next(req)
+				if err == nil && resp.StatusCode < http.StatusInternalServerError {
+					return resp, nil
+				}
+
+				if attempt >= maxRetries {
+					return resp, err
+				}
+
+				if !req.LastBodyOptions.Replayable {
+					if resp != nil {
+						_ = resp.Body.Close()
+					}
+
+					return nil, ErrBodyNotReplayable
+				}
+
+				if resp != nil {
+					_ = resp.Body.Close()
+				}
+
+				timer := time.NewTimer(wait)
+				select {
+				case <-req.Ctx.Done():
+					timer.Stop()
+					return nil, req.Ctx.Err()
+				case <-timer.C:
+				}
+
+				wait *= 2
+				if wait > max {
+					wait = max
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,244 @@
+package gotbot
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/roskee/gotbot/entity"
+)
+
+func TestRetryAfterFromResponse(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		fallback time.Duration
+		want     time.Duration
+	}{
+		{
+			name:     "retry_after present",
+			body:     `{"ok":false,"error_code":429,"parameters":{"retry_after":5}}`,
+			fallback: time.Second,
+			want:     5 * time.Second,
+		},
+		{
+			name:     "no parameters falls back",
+			body:     `{"ok":false,"error_code":429}`,
+			fallback: 2 * time.Second,
+			want:     2 * time.Second,
+		},
+		{
+			name:     "zero retry_after falls back",
+			body:     `{"ok":false,"error_code":429,"parameters":{"retry_after":0}}`,
+			fallback: 3 * time.Second,
+			want:     3 * time.Second,
+		},
+		{
+			name:     "invalid json falls back",
+			body:     `not json`,
+			fallback: time.Second,
+			want:     time.Second,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &http.Response{Body: io.NopCloser(strings.NewReader(tt.body))}
+
+			got := retryAfterFromResponse(resp, tt.fallback)
+			if got != tt.want {
+				t.Errorf("retryAfterFromResponse() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func newJSONResponse(status int, body string) *http.Response {
+	return &http.Response{StatusCode: status, Body: io.NopCloser(strings.NewReader(body))}
+}
+
+func TestRetryAfterMiddlewareRefusesNonReplayableBody(t *testing.T) {
+	attempts := 0
+	next := func(req *Request) (*http.Response, error) {
+		attempts++
+		_, opts, err := req.Body()
+		if err != nil {
+			return nil, err
+		}
+		req.LastBodyOptions = opts
+		return newJSONResponse(http.StatusTooManyRequests, `{"ok":false,"error_code":429,"parameters":{"retry_after":0}}`), nil
+	}
+
+	rt := RetryAfterMiddleware(3, time.Millisecond)(next)
+
+	body := GetMultipartBody(struct{}{}, entity.FileEnvelop{Name: "file0", Reader: bytes.NewReader([]byte("data"))})
+
+	_, err := rt(&Request{Ctx: context.Background(), Body: body})
+	if !errors.Is(err, ErrBodyNotReplayable) {
+		t.Fatalf("err = %v, want ErrBodyNotReplayable", err)
+	}
+
+	if attempts != 1 {
+		t.Errorf("attempts = %d, want 1 (no retry of a non-replayable body)", attempts)
+	}
+}
+
+func TestRetryAfterMiddlewareRetriesReplayableBody(t *testing.T) {
+	attempts := 0
+	next := func(req *Request) (*http.Response, error) {
+		attempts++
+		_, opts, err := req.Body()
+		if err != nil {
+			return nil, err
+		}
+		req.LastBodyOptions = opts
+
+		if attempts < 2 {
+			return newJSONResponse(http.StatusTooManyRequests, `{"ok":false,"error_code":429,"parameters":{"retry_after":0}}`), nil
+		}
+
+		return newJSONResponse(http.StatusOK, `{"ok":true}`), nil
+	}
+
+	rt := RetryAfterMiddleware(3, time.Millisecond)(next)
+
+	body := GetJSONBody(map[string]any{"chat_id": 1})
+
+	resp, err := rt(&Request{Ctx: context.Background(), Body: body})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("StatusCode = %d, want 200", resp.StatusCode)
+	}
+
+	if attempts != 2 {
+		t.Errorf("attempts = %d, want 2", attempts)
+	}
+}
+
+func TestRateLimitMiddlewareBlocksSameChatUntilRefill(t *testing.T) {
+	next := func(req *Request) (*http.Response, error) {
+		return newJSONResponse(http.StatusOK, `{"ok":true}`), nil
+	}
+
+	rt := RateLimitMiddleware(100, 2)(next)
+	ctx := WithChatID(context.Background(), 42)
+
+	start := time.Now()
+
+	if _, err := rt(&Request{Ctx: ctx}); err != nil {
+		t.Fatalf("first call: %v", err)
+	}
+
+	if _, err := rt(&Request{Ctx: ctx}); err != nil {
+		t.Fatalf("second call: %v", err)
+	}
+
+	// The per-chat bucket holds 2 tokens, so a third same-chat call must
+	// block for roughly 1/perChatPerSecond before a token refills.
+	if _, err := rt(&Request{Ctx: ctx}); err != nil {
+		t.Fatalf("third call: %v", err)
+	}
+
+	if elapsed := time.Since(start); elapsed < 400*time.Millisecond {
+		t.Errorf("third call returned after %v, want it to block for a refill (~500ms)", elapsed)
+	}
+}
+
+func TestRateLimitMiddlewareUnscopedRequestsShareOnlyGlobalBucket(t *testing.T) {
+	next := func(req *Request) (*http.Response, error) {
+		return newJSONResponse(http.StatusOK, `{"ok":true}`), nil
+	}
+
+	rt := RateLimitMiddleware(100, 1)(next)
+
+	start := time.Now()
+
+	for i := 0; i < 5; i++ {
+		if _, err := rt(&Request{Ctx: context.Background()}); err != nil {
+			t.Fatalf("call %d: %v", i, err)
+		}
+	}
+
+	if elapsed := time.Since(start); elapsed > 200*time.Millisecond {
+		t.Errorf("requests with no chat ID took %v, want them to bypass the per-chat bucket", elapsed)
+	}
+}
+
+func TestBackoffMiddlewareDoublesWaitUpToMax(t *testing.T) {
+	var waits []time.Duration
+	var last time.Time
+
+	attempts := 0
+	next := func(req *Request) (*http.Response, error) {
+		attempts++
+		now := time.Now()
+		if !last.IsZero() {
+			waits = append(waits, now.Sub(last))
+		}
+		last = now
+
+		_, opts, err := req.Body()
+		if err != nil {
+			return nil, err
+		}
+		req.LastBodyOptions = opts
+
+		return newJSONResponse(http.StatusServiceUnavailable, ""), nil
+	}
+
+	rt := BackoffMiddleware(3, 10*time.Millisecond, 30*time.Millisecond)(next)
+
+	resp, err := rt(&Request{Ctx: context.Background(), Body: GetJSONBody(map[string]any{})})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("StatusCode = %d, want 503", resp.StatusCode)
+	}
+
+	if attempts != 4 {
+		t.Fatalf("attempts = %d, want 4 (1 initial + 3 retries)", attempts)
+	}
+
+	wantWaits := []time.Duration{10 * time.Millisecond, 20 * time.Millisecond, 30 * time.Millisecond}
+	for i, want := range wantWaits {
+		if waits[i] < want {
+			t.Errorf("wait[%d] = %v, want at least %v", i, waits[i], want)
+		}
+	}
+}
+
+func TestBackoffMiddlewareGivesUpAfterMaxRetries(t *testing.T) {
+	attempts := 0
+	next := func(req *Request) (*http.Response, error) {
+		attempts++
+
+		_, opts, err := req.Body()
+		if err != nil {
+			return nil, err
+		}
+		req.LastBodyOptions = opts
+
+		return nil, errors.New("network unreachable")
+	}
+
+	rt := BackoffMiddleware(2, time.Millisecond, time.Millisecond)(next)
+
+	_, err := rt(&Request{Ctx: context.Background(), Body: GetJSONBody(map[string]any{})})
+	if err == nil {
+		t.Fatal("expected an error after exhausting retries")
+	}
+
+	if attempts != 3 {
+		t.Errorf("attempts = %d, want 3 (1 initial + 2 retries)", attempts)
+	}
+}
@@ -0,0 +1,89 @@
+package gotbot
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+
+	"github.com/roskee/gotbot/entity"
+)
+
+// PollOptions configures Bot.Poll.
+type PollOptions struct {
+	// Offset is the update_id to start polling from; it is advanced
+	// automatically as updates are consumed.
+	Offset int64
+	// Timeout is getUpdates' long-polling timeout, in seconds.
+	Timeout int
+	// AllowedUpdates restricts which update types are delivered; nil means
+	// Telegram's default (all except ones explicitly dropped by a
+	// previous setWebhook call).
+	AllowedUpdates []string
+}
+
+// Poll long-polls getUpdates and feeds every Update it receives to
+// dispatcher.Dispatch, persisting the update_id offset between calls. It
+// blocks until ctx is done or a getUpdates call or Handler returns an
+// error.
+func (b *Bot) Poll(ctx context.Context, dispatcher *Dispatcher, opts PollOptions) error {
+	offset := opts.Offset
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+
+		var updates []entity.Update
+		err := b.Do(ctx, SetApplicationJSON, "getUpdates", GetJSONBody(map[string]any{
+			"offset":          offset,
+			"timeout":         opts.Timeout,
+			"allowed_updates": opts.AllowedUpdates,
+		}), &updates)
+		if err != nil {
+			return err
+		}
+
+		for i := range updates {
+			offset = updates[i].UpdateID + 1
+
+			if err := dispatcher.Dispatch(&Context{Context: ctx, Bot: b, Update: &updates[i]}); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// WebhookSecretHeader is the header Telegram echoes the secret_token
+// configured via setWebhook in, so a handler can authenticate incoming
+// requests as actually coming from Telegram.
+const WebhookSecretHeader = "X-Telegram-Bot-Api-Secret-Token"
+
+// Webhook registers a handler on mux at path that decodes incoming
+// Telegram webhook requests and feeds them to dispatcher.Dispatch. When
+// secretToken is non-empty, requests whose WebhookSecretHeader doesn't
+// match it are rejected with 401 Unauthorized.
+func (b *Bot) Webhook(mux *http.ServeMux, path string, dispatcher *Dispatcher, secretToken string) {
+	mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+		if secretToken != "" && subtle.ConstantTimeCompare(
+			[]byte(r.Header.Get(WebhookSecretHeader)), []byte(secretToken)) != 1 {
+			http.Error(w, "invalid secret token", http.StatusUnauthorized)
+			return
+		}
+
+		var update entity.Update
+		if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+			http.Error(w, "invalid update", http.StatusBadRequest)
+			return
+		}
+
+		if err := dispatcher.Dispatch(&Context{Context: r.Context(), Bot: b, Update: &update}); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+	})
+}
@@ -0,0 +1,148 @@
+package gotbot
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestWebhookSecretCheck(t *testing.T) {
+	var dispatched bool
+	d := NewDispatcher("")
+	d.OnText(func(*Context) error { dispatched = true; return nil })
+
+	bot := &Bot{}
+	mux := http.NewServeMux()
+	bot.Webhook(mux, "/webhook", d, "correct-secret")
+
+	body := `{"update_id":1,"message":{"chat":{"id":1},"text":"hi"}}`
+
+	t.Run("missing secret is rejected", func(t *testing.T) {
+		dispatched = false
+		req := httptest.NewRequest(http.MethodPost, "/webhook", strings.NewReader(body))
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusUnauthorized {
+			t.Errorf("status = %d, want %d", rec.Code, http.StatusUnauthorized)
+		}
+
+		if dispatched {
+			t.Error("handler should not have been dispatched")
+		}
+	})
+
+	t.Run("wrong secret is rejected", func(t *testing.T) {
+		dispatched = false
+		req := httptest.NewRequest(http.MethodPost, "/webhook", strings.NewReader(body))
+		req.Header.Set(WebhookSecretHeader, "wrong-secret")
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusUnauthorized {
+			t.Errorf("status = %d, want %d", rec.Code, http.StatusUnauthorized)
+		}
+
+		if dispatched {
+			t.Error("handler should not have been dispatched")
+		}
+	})
+
+	t.Run("correct secret is dispatched", func(t *testing.T) {
+		dispatched = false
+		req := httptest.NewRequest(http.MethodPost, "/webhook", strings.NewReader(body))
+		req.Header.Set(WebhookSecretHeader, "correct-secret")
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusOK {
+			t.Errorf("status = %d, want %d", rec.Code, http.StatusOK)
+		}
+
+		if !dispatched {
+			t.Error("handler should have been dispatched")
+		}
+	})
+}
+
+func TestBotPollAdvancesOffsetAndDispatches(t *testing.T) {
+	var gotOffsets []int64
+	var texts []string
+
+	attempts := 0
+	bot := newFakeBot(func(req *http.Request) (*http.Response, error) {
+		attempts++
+
+		defer func() {
+			_ = req.Body.Close()
+		}()
+
+		var body struct {
+			Offset int64 `json:"offset"`
+		}
+		if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+			t.Fatalf("decoding request body: %v", err)
+		}
+		gotOffsets = append(gotOffsets, body.Offset)
+
+		if attempts == 1 {
+			return jsonResponse(http.StatusOK, `{"ok":true,"result":[
+				{"update_id":5,"message":{"chat":{"id":1},"text":"hi"}},
+				{"update_id":6,"message":{"chat":{"id":1},"text":"there"}}
+			]}`), nil
+		}
+
+		return nil, errors.New("network unreachable")
+	})
+
+	d := NewDispatcher("")
+	d.OnText(func(ctx *Context) error { texts = append(texts, ctx.Message.Text); return nil })
+
+	err := bot.Poll(context.Background(), d, PollOptions{})
+	if err == nil || !strings.Contains(err.Error(), "network unreachable") {
+		t.Fatalf("Poll err = %v, want it to propagate the second getUpdates failure", err)
+	}
+
+	if len(texts) != 2 || texts[0] != "hi" || texts[1] != "there" {
+		t.Errorf("dispatched texts = %v, want [hi there]", texts)
+	}
+
+	if len(gotOffsets) != 2 || gotOffsets[0] != 0 || gotOffsets[1] != 7 {
+		t.Errorf("offsets sent = %v, want [0 7]", gotOffsets)
+	}
+}
+
+func TestBotPollStopsOnHandlerError(t *testing.T) {
+	wantErr := errors.New("handler failed")
+
+	bot := newFakeBot(func(req *http.Request) (*http.Response, error) {
+		return jsonResponse(http.StatusOK, `{"ok":true,"result":[
+			{"update_id":1,"message":{"chat":{"id":1},"text":"hi"}}
+		]}`), nil
+	})
+
+	d := NewDispatcher("")
+	d.OnText(func(*Context) error { return wantErr })
+
+	if err := bot.Poll(context.Background(), d, PollOptions{}); !errors.Is(err, wantErr) {
+		t.Fatalf("Poll err = %v, want %v", err, wantErr)
+	}
+}
+
+func TestBotPollStopsOnContextDone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	bot := newFakeBot(func(req *http.Request) (*http.Response, error) {
+		t.Fatal("getUpdates should not be called once ctx is already done")
+		return nil, nil
+	})
+
+	if err := bot.Poll(ctx, NewDispatcher(""), PollOptions{}); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Poll err = %v, want context.Canceled", err)
+	}
+}